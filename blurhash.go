@@ -0,0 +1,151 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// blurHashAlphabet is the 83-character base83 alphabet used by the BlurHash
+// encoding (https://blurha.sh).
+const blurHashAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// BlurHash computes a compact BlurHash placeholder string for img, encoding
+// componentsX*componentsY DCT basis coefficients. Both componentsX and
+// componentsY must be in [1, 9]. BlurHash is the placeholder format used by
+// many image pipelines (fediverse servers, chat clients) to show a small
+// blurred preview before the real thumbnail loads.
+//
+// Usage example:
+//
+//	hash, err := imaging.BlurHash(srcImage, 4, 3)
+//
+func BlurHash(img image.Image, componentsX, componentsY int) (string, error) {
+	if componentsX < 1 || componentsX > 9 || componentsY < 1 || componentsY > 9 {
+		return "", fmt.Errorf("imaging: componentsX and componentsY must be in [1, 9]")
+	}
+
+	src := newScanner(img)
+	w, h := src.w, src.h
+	pix := make([]uint8, w*h*4)
+	src.scan(0, 0, w, h, pix)
+
+	linear := make([][3]float64, w*h)
+	for i := range linear {
+		linear[i] = [3]float64{
+			srgbToLinear(pix[i*4+0]),
+			srgbToLinear(pix[i*4+1]),
+			srgbToLinear(pix[i*4+2]),
+		}
+	}
+
+	factors := make([][3]float64, componentsX*componentsY)
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			norm := 2.0
+			if i == 0 && j == 0 {
+				norm = 1.0
+			}
+
+			var r, g, b float64
+			for y := 0; y < h; y++ {
+				cosY := math.Cos(math.Pi * float64(j) * float64(y) / float64(h))
+				for x := 0; x < w; x++ {
+					cosX := math.Cos(math.Pi * float64(i) * float64(x) / float64(w))
+					basis := cosX * cosY
+					c := linear[y*w+x]
+					r += basis * c[0]
+					g += basis * c[1]
+					b += basis * c[2]
+				}
+			}
+
+			scale := norm / float64(w*h)
+			factors[j*componentsX+i] = [3]float64{r * scale, g * scale, b * scale}
+		}
+	}
+
+	var maxAC float64
+	for _, c := range factors[1:] {
+		for _, v := range c {
+			if a := math.Abs(v); a > maxAC {
+				maxAC = a
+			}
+		}
+	}
+
+	quant := 0
+	actualMax := 1.0
+	if maxAC > 0 {
+		quant = clampInt(int(math.Floor(maxAC*166-0.5)), 0, 82)
+		actualMax = float64(quant+1) / 166
+	}
+
+	out := make([]byte, 0, 4+2*(len(factors)-1))
+	out = base83Encode(out, (componentsX-1)+(componentsY-1)*9, 1)
+	out = base83Encode(out, quant, 1)
+	out = base83Encode(out, encodeDC(factors[0]), 4)
+	for _, c := range factors[1:] {
+		out = base83Encode(out, encodeAC(c, actualMax), 2)
+	}
+
+	return string(out), nil
+}
+
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	var c float64
+	if v <= 0.0031308 {
+		c = v * 12.92
+	} else {
+		c = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return clampInt(int(math.Round(c*255)), 0, 255)
+}
+
+func encodeDC(c [3]float64) int {
+	return linearToSRGB(c[0])<<16 | linearToSRGB(c[1])<<8 | linearToSRGB(c[2])
+}
+
+func encodeAC(c [3]float64, actualMax float64) int {
+	quantize := func(v float64) int {
+		v /= actualMax
+		sign := 1.0
+		if v < 0 {
+			sign = -1.0
+		}
+		q := sign*math.Pow(math.Abs(v), 0.5)*9 + 9.5
+		return clampInt(int(math.Floor(q)), 0, 18)
+	}
+
+	return quantize(c[0])*19*19 + quantize(c[1])*19 + quantize(c[2])
+}
+
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func base83Encode(dst []byte, value, digits int) []byte {
+	for i := digits - 1; i >= 0; i-- {
+		p := 1
+		for k := 0; k < i; k++ {
+			p *= 83
+		}
+		dst = append(dst, blurHashAlphabet[(value/p)%83])
+	}
+	return dst
+}
@@ -0,0 +1,52 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBlurHashInvalidComponents(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	draw(src, color.NRGBA{128, 128, 128, 255})
+
+	for _, tc := range []struct{ x, y int }{
+		{0, 3}, {10, 3}, {3, 0}, {3, 10},
+	} {
+		if _, err := BlurHash(src, tc.x, tc.y); err == nil {
+			t.Errorf("BlurHash(img, %d, %d) did not return an error", tc.x, tc.y)
+		}
+	}
+}
+
+func TestBlurHashLength(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 32, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 32; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x * 8), uint8(y * 8), 128, 255})
+		}
+	}
+
+	componentsX, componentsY := 4, 3
+	hash, err := BlurHash(src, componentsX, componentsY)
+	if err != nil {
+		t.Fatalf("BlurHash returned an error: %v", err)
+	}
+
+	want := 1 + 1 + 4 + 2*(componentsX*componentsY-1)
+	if len(hash) != want {
+		t.Errorf("got hash length %d, want %d", len(hash), want)
+	}
+	for _, c := range hash {
+		found := false
+		for _, a := range blurHashAlphabet {
+			if c == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("hash contains character %q outside the base83 alphabet", c)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// ConvolveOption configures ConvolveSeparable and Convolve.
+type ConvolveOption func(*convolveConfig)
+
+type edgeHandling int
+
+const (
+	edgeClamp edgeHandling = iota
+	edgeWrap
+	edgeMirror
+	edgeConstant
+)
+
+type convolveConfig struct {
+	edge      edgeHandling
+	edgeColor color.NRGBA
+	normalize bool
+	bias      float64
+}
+
+func newConvolveConfig(options []ConvolveOption) convolveConfig {
+	cfg := convolveConfig{edge: edgeClamp}
+	for _, opt := range options {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// EdgeClamp extends the nearest edge pixel for samples that fall outside the
+// image. This is the default edge handling.
+func EdgeClamp() ConvolveOption {
+	return func(c *convolveConfig) { c.edge = edgeClamp }
+}
+
+// EdgeWrap wraps out-of-bounds samples around to the opposite edge.
+func EdgeWrap() ConvolveOption {
+	return func(c *convolveConfig) { c.edge = edgeWrap }
+}
+
+// EdgeMirror reflects out-of-bounds samples back across the edge.
+func EdgeMirror() ConvolveOption {
+	return func(c *convolveConfig) { c.edge = edgeMirror }
+}
+
+// EdgeConstant returns col for every sample that falls outside the image.
+func EdgeConstant(col color.NRGBA) ConvolveOption {
+	return func(c *convolveConfig) {
+		c.edge = edgeConstant
+		c.edgeColor = col
+	}
+}
+
+// Normalize divides the output alpha by the sum of the absolute kernel
+// weights that contributed to it, which is what Blur-style averaging
+// kernels need to keep alpha meaningful. Color channels are always
+// unpremultiplied by the alpha actually sampled, regardless of this option,
+// since that is required to get back to straight color and not merely a
+// normalization choice; edge-detection kernels (Sobel, Laplacian, emboss)
+// that intentionally sum to zero or less than one should leave this off
+// (the default) and use Bias to recenter the result.
+func Normalize(v bool) ConvolveOption {
+	return func(c *convolveConfig) { c.normalize = v }
+}
+
+// Bias adds v to each of the R, G, and B channels after normalization,
+// which is useful for kernels (e.g. Laplacian, emboss) whose output is
+// centered on zero rather than on the mid-range of a channel.
+func Bias(v float64) ConvolveOption {
+	return func(c *convolveConfig) { c.bias = v }
+}
+
+// index maps a coordinate that may fall outside [0, n) to an in-bounds
+// coordinate according to cfg's edge handling. ok is false only for
+// edgeConstant, in which case the caller should use cfg.edgeColor instead of
+// sampling the image.
+func (c *convolveConfig) index(i, n int) (int, bool) {
+	switch c.edge {
+	case edgeWrap:
+		i %= n
+		if i < 0 {
+			i += n
+		}
+		return i, true
+	case edgeMirror:
+		if n == 1 {
+			return 0, true
+		}
+		period := 2 * n
+		i %= period
+		if i < 0 {
+			i += period
+		}
+		if i >= n {
+			i = period - 1 - i
+		}
+		return i, true
+	case edgeConstant:
+		if i < 0 || i >= n {
+			return 0, false
+		}
+		return i, true
+	default: // edgeClamp
+		if i < 0 {
+			i = 0
+		} else if i > n-1 {
+			i = n - 1
+		}
+		return i, true
+	}
+}
+
+// ConvolveSeparable convolves img with the separable kernel (kernelH,
+// kernelV), applying kernelH along rows and kernelV along columns. Each
+// kernel is centered on index (len(kernel)-1)/2. Color channels are always
+// unpremultiplied by the sampled alpha sum, regardless of options; by
+// default samples outside the image extend the nearest edge pixel and
+// alpha is left as that raw alpha sum, and pass EdgeWrap, EdgeMirror,
+// EdgeConstant, Normalize, or Bias to change that.
+//
+// Usage example:
+//
+//	dstImage := imaging.ConvolveSeparable(srcImage, kernel, kernel, imaging.Normalize(true))
+//
+func ConvolveSeparable(img image.Image, kernelH, kernelV []float64, options ...ConvolveOption) *image.NRGBA {
+	cfg := newConvolveConfig(options)
+	// Bias is applied once, in the vertical pass, not after each pass.
+	hcfg := cfg
+	hcfg.bias = 0
+	return convolveVertical(convolveHorizontal(img, kernelH, hcfg), kernelV, cfg)
+}
+
+// Convolve convolves img with the 2D kernel in a single pass. kernel[ky][kx]
+// is centered on kernel[(len(kernel)-1)/2][(len(kernel[0])-1)/2]. Color
+// channels are always unpremultiplied by the sampled alpha sum, regardless
+// of options; by default samples outside the image extend the nearest edge
+// pixel and alpha is left as that raw alpha sum, and pass EdgeWrap,
+// EdgeMirror, EdgeConstant, Normalize, or Bias to change that. Prefer
+// ConvolveSeparable when the kernel is the outer product of two 1D kernels,
+// since that is significantly cheaper.
+//
+// Usage example:
+//
+//	dstImage := imaging.Convolve(srcImage, sobelX, imaging.Bias(128))
+//
+func Convolve(img image.Image, kernel [][]float64, options ...ConvolveOption) *image.NRGBA {
+	cfg := newConvolveConfig(options)
+
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+
+	pix := make([]uint8, src.w*src.h*4)
+	src.scan(0, 0, src.w, src.h, pix)
+	stride := src.w * 4
+
+	kh := len(kernel)
+	kw := 0
+	if kh > 0 {
+		kw = len(kernel[0])
+	}
+	centerY := (kh - 1) / 2
+	centerX := (kw - 1) / 2
+
+	at := func(x, y int) (float64, float64, float64, float64) {
+		ix, okx := cfg.index(x, src.w)
+		iy, oky := cfg.index(y, src.h)
+		if !okx || !oky {
+			return float64(cfg.edgeColor.R), float64(cfg.edgeColor.G), float64(cfg.edgeColor.B), float64(cfg.edgeColor.A)
+		}
+		i := iy*stride + ix*4
+		return float64(pix[i]), float64(pix[i+1]), float64(pix[i+2]), float64(pix[i+3])
+	}
+
+	parallel(0, src.h, func(ys <-chan int) {
+		for y := range ys {
+			j := y * dst.Stride
+			for x := 0; x < src.w; x++ {
+				var r, g, b, alphaSum, wsumAbs float64
+				for ky := 0; ky < kh; ky++ {
+					row := kernel[ky]
+					for kx := 0; kx < kw; kx++ {
+						weight := row[kx]
+						absWeight := math.Abs(weight)
+						sr, sg, sb, sa := at(x+kx-centerX, y+ky-centerY)
+						wa := sa * weight
+						r += sr * wa
+						g += sg * wa
+						b += sb * wa
+						alphaSum += sa * absWeight
+						wsumAbs += absWeight
+					}
+				}
+
+				// Unpremultiplying by alphaSum converts the premultiplied
+				// accumulation back to straight color; this must happen
+				// unconditionally, since kernels whose signed weights sum
+				// near zero (Sobel, Laplacian) would otherwise divide by
+				// ~0 if this were gated behind Normalize.
+				if alphaSum != 0 {
+					r /= alphaSum
+					g /= alphaSum
+					b /= alphaSum
+				}
+
+				outAlpha := alphaSum
+				if cfg.normalize && wsumAbs != 0 {
+					outAlpha /= wsumAbs
+				}
+
+				dst.Pix[j+0] = clamp(r + cfg.bias)
+				dst.Pix[j+1] = clamp(g + cfg.bias)
+				dst.Pix[j+2] = clamp(b + cfg.bias)
+				dst.Pix[j+3] = clamp(outAlpha)
+				j += 4
+			}
+		}
+	})
+
+	return dst
+}
+
+func convolveHorizontal(img image.Image, kernel []float64, cfg convolveConfig) *image.NRGBA {
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+	center := (len(kernel) - 1) / 2
+
+	parallel(0, src.h, func(ys <-chan int) {
+		scanLine := make([]uint8, src.w*4)
+		scanLineF := make([]float64, len(scanLine))
+		for y := range ys {
+			src.scan(0, y, src.w, y+1, scanLine)
+			for i, v := range scanLine {
+				scanLineF[i] = float64(v)
+			}
+
+			for x, idx := 0, 0; x < src.w; x, idx = x+1, idx+4 {
+				var r, g, b, alphaSum, wsumAbs float64
+				for k, weight := range kernel {
+					ix, ok := cfg.index(x+k-center, src.w)
+
+					var sr, sg, sb, sa float64
+					if ok {
+						i := ix * 4
+						sr, sg, sb, sa = scanLineF[i], scanLineF[i+1], scanLineF[i+2], scanLineF[i+3]
+					} else {
+						sr, sg, sb, sa = float64(cfg.edgeColor.R), float64(cfg.edgeColor.G), float64(cfg.edgeColor.B), float64(cfg.edgeColor.A)
+					}
+
+					absWeight := math.Abs(weight)
+					wa := sa * weight
+					r += sr * wa
+					g += sg * wa
+					b += sb * wa
+					alphaSum += sa * absWeight
+					wsumAbs += absWeight
+				}
+
+				// See the comment in Convolve: unpremultiplying by alphaSum
+				// must not be gated behind Normalize.
+				if alphaSum != 0 {
+					r /= alphaSum
+					g /= alphaSum
+					b /= alphaSum
+				}
+
+				outAlpha := alphaSum
+				if cfg.normalize && wsumAbs != 0 {
+					outAlpha /= wsumAbs
+				}
+
+				scanLine[idx] = clamp(r + cfg.bias)
+				scanLine[idx+1] = clamp(g + cfg.bias)
+				scanLine[idx+2] = clamp(b + cfg.bias)
+				scanLine[idx+3] = clamp(outAlpha)
+			}
+			copy(dst.Pix[y*dst.Stride:], scanLine)
+		}
+	})
+
+	return dst
+}
+
+func convolveVertical(img image.Image, kernel []float64, cfg convolveConfig) *image.NRGBA {
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+	center := (len(kernel) - 1) / 2
+
+	parallel(0, src.w, func(xs <-chan int) {
+		scanLine := make([]uint8, src.h*4)
+		scanLineF := make([]float64, len(scanLine))
+		for x := range xs {
+			src.scan(x, 0, x+1, src.h, scanLine)
+			for i, v := range scanLine {
+				scanLineF[i] = float64(v)
+			}
+
+			for y := 0; y < src.h; y++ {
+				var r, g, b, alphaSum, wsumAbs float64
+				for k, weight := range kernel {
+					iy, ok := cfg.index(y+k-center, src.h)
+
+					var sr, sg, sb, sa float64
+					if ok {
+						i := iy * 4
+						sr, sg, sb, sa = scanLineF[i], scanLineF[i+1], scanLineF[i+2], scanLineF[i+3]
+					} else {
+						sr, sg, sb, sa = float64(cfg.edgeColor.R), float64(cfg.edgeColor.G), float64(cfg.edgeColor.B), float64(cfg.edgeColor.A)
+					}
+
+					absWeight := math.Abs(weight)
+					wa := sa * weight
+					r += sr * wa
+					g += sg * wa
+					b += sb * wa
+					alphaSum += sa * absWeight
+					wsumAbs += absWeight
+				}
+
+				// See the comment in Convolve: unpremultiplying by alphaSum
+				// must not be gated behind Normalize.
+				if alphaSum != 0 {
+					r /= alphaSum
+					g /= alphaSum
+					b /= alphaSum
+				}
+
+				outAlpha := alphaSum
+				if cfg.normalize && wsumAbs != 0 {
+					outAlpha /= wsumAbs
+				}
+
+				j := y*dst.Stride + x*4
+				dst.Pix[j+0] = clamp(r + cfg.bias)
+				dst.Pix[j+1] = clamp(g + cfg.bias)
+				dst.Pix[j+2] = clamp(b + cfg.bias)
+				dst.Pix[j+3] = clamp(outAlpha)
+			}
+		}
+	})
+
+	return dst
+}
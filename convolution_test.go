@@ -0,0 +1,89 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBlurFlatColorIsUnchanged(t *testing.T) {
+	// Regression test for the Blur/Sharpen rewrite onto ConvolveSeparable:
+	// a flat image must still blur to itself.
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	want := color.NRGBA{210, 40, 150, 255}
+	draw(src, want)
+
+	dst := Blur(src, 4)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		got := color.NRGBA{dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]}
+		if got != want {
+			t.Fatalf("Blur of a flat image changed pixel value: got %v, want %v", got, want)
+		}
+	}
+
+	sdst := Sharpen(src, 4)
+	for i := 0; i < len(sdst.Pix); i += 4 {
+		got := color.NRGBA{sdst.Pix[i], sdst.Pix[i+1], sdst.Pix[i+2], sdst.Pix[i+3]}
+		if got != want {
+			t.Fatalf("Sharpen of a flat image changed pixel value: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestConvolveSeparableNormalizedAverageIsFlat(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	want := color.NRGBA{30, 60, 90, 255}
+	draw(src, want)
+
+	kernel := []float64{1, 1, 1}
+	dst := ConvolveSeparable(src, kernel, kernel, Normalize(true), EdgeClamp())
+	for i := 0; i < len(dst.Pix); i += 4 {
+		got := color.NRGBA{dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]}
+		if got != want {
+			t.Fatalf("normalized box-average convolution of a flat image changed pixel value: got %v, want %v", got, want)
+		}
+	}
+}
+
+// TestConvolveSobelOnOpaqueImageStaysOpaque is a regression test for a bug
+// where premultiplied-alpha unpremultiplication was gated behind
+// Normalize, so a Sobel-style kernel (whose signed weights sum near zero)
+// divided color by a near-zero accumulated alpha and produced a fully
+// transparent, garbage-saturated result on an ordinary opaque image.
+func TestConvolveSobelOnOpaqueImageStaysOpaque(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			v := uint8(0)
+			if x >= 10 {
+				v = 255
+			}
+			src.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+
+	sobelX := [][]float64{
+		{-1, 0, 1},
+		{-2, 0, 2},
+		{-1, 0, 1},
+	}
+
+	dst := Convolve(src, sobelX, Bias(128))
+
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			if a := dst.NRGBAAt(x, y).A; a != 255 {
+				t.Fatalf("pixel (%d,%d) has alpha %d on an opaque source image, want 255", x, y, a)
+			}
+		}
+	}
+
+	flat := dst.NRGBAAt(2, 10).R
+	edge := dst.NRGBAAt(10, 10).R
+	if flat != 128 {
+		t.Errorf("flat region away from the edge: got R=%d, want 128 (no gradient)", flat)
+	}
+	if edge == flat {
+		t.Errorf("pixel straddling the edge did not register any gradient: got R=%d", edge)
+	}
+}
@@ -22,113 +22,360 @@ func Blur(img image.Image, sigma float64) *image.NRGBA {
 	}
 
 	radius := int(math.Ceil(sigma * 3.0))
-	kernel := make([]float64, radius+1)
+	kernel := make([]float64, 2*radius+1)
+	for i := -radius; i <= radius; i++ {
+		kernel[i+radius] = gaussianBlurKernel(float64(i), sigma)
+	}
+
+	return ConvolveSeparable(img, kernel, kernel, Normalize(true), EdgeClamp())
+}
 
-	for i := 0; i <= radius; i++ {
-		kernel[i] = gaussianBlurKernel(float64(i), sigma)
+// BlurFast produces a blurred version of the image using a fast approximation
+// of a Gaussian blur obtained by running three successive box blurs.
+// Sigma parameter must be positive and indicates how much the image will be blurred.
+// Unlike Blur, the cost of BlurFast does not grow with sigma, which makes it a
+// good fit for the large sigmas used by thumbnail and preview pipelines.
+//
+// Usage example:
+//
+//	dstImage := imaging.BlurFast(srcImage, 25.0)
+//
+func BlurFast(img image.Image, sigma float64) *image.NRGBA {
+	if sigma <= 0 {
+		return Clone(img)
 	}
 
-	return blurVertical(blurHorizontal(img, kernel), kernel)
+	dst := Clone(img)
+	for _, w := range boxesForGauss(sigma, 3) {
+		dst = boxBlurVertical(boxBlurHorizontal(dst, w), w)
+	}
+	return dst
+}
+
+// boxesForGauss computes n box-blur widths that together approximate a
+// Gaussian blur with the given sigma, following the method described by
+// Ivan Kutskir (http://blog.ivank.net/fastest-gaussian-blur.html).
+func boxesForGauss(sigma float64, n int) []int {
+	wIdeal := math.Sqrt(12*sigma*sigma/float64(n) + 1)
+	wl := int(math.Floor(wIdeal))
+	if wl%2 == 0 {
+		wl--
+	}
+	wu := wl + 2
+
+	mIdeal := (12*sigma*sigma - float64(n)*float64(wl*wl) - 4*float64(n)*float64(wl) - 3*float64(n)) / (-4*float64(wl) - 4)
+	m := int(math.Round(mIdeal))
+
+	boxes := make([]int, n)
+	for i := range boxes {
+		if i < m {
+			boxes[i] = wl
+		} else {
+			boxes[i] = wu
+		}
+	}
+	return boxes
 }
 
-func blurHorizontal(img image.Image, kernel []float64) *image.NRGBA {
+// boxBlurHorizontal runs a uniformly-weighted box blur of the given width
+// along the x axis using a sliding-window sum, so its cost is independent of
+// the box width. Color is averaged weighted by alpha and alpha is averaged
+// over the samples in the window, the same premultiplied way as convolveHorizontal.
+func boxBlurHorizontal(img image.Image, w int) *image.NRGBA {
 	src := newScanner(img)
 	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
-	radius := len(kernel) - 1
+	if w <= 1 {
+		src.scan(0, 0, src.w, src.h, dst.Pix)
+		return dst
+	}
+	radius := w / 2
 
 	parallel(0, src.h, func(ys <-chan int) {
 		scanLine := make([]uint8, src.w*4)
-		scanLineF := make([]float64, len(scanLine))
+		out := make([]uint8, src.w*4)
 		for y := range ys {
 			src.scan(0, y, src.w, y+1, scanLine)
-			for i, v := range scanLine {
-				scanLineF[i] = float64(v)
+
+			add := func(x int, r, g, b, a *float64) {
+				i := x * 4
+				wa := float64(scanLine[i+3])
+				*r += float64(scanLine[i+0]) * wa
+				*g += float64(scanLine[i+1]) * wa
+				*b += float64(scanLine[i+2]) * wa
+				*a += wa
 			}
+
+			var r, g, b, a, wsum float64
+			min, max := 0, -1
+			for ix := 0; ix <= radius && ix < src.w; ix++ {
+				add(ix, &r, &g, &b, &a)
+				wsum++
+				max = ix
+			}
+
 			for x, idx := 0, 0; x < src.w; x, idx = x+1, idx+4 {
-				min := x - radius
-				if min < 0 {
-					min = 0
-				}
-				max := x + radius
-				if max > src.w-1 {
-					max = src.w - 1
+				if a != 0 {
+					out[idx] = clamp(r / a)
+					out[idx+1] = clamp(g / a)
+					out[idx+2] = clamp(b / a)
 				}
+				out[idx+3] = clamp(a / wsum)
 
-				var r, g, b, a, wsum float64
-				for ix := min; ix <= max; ix++ {
-					i := ix * 4
-					weight := kernel[absint(x-ix)]
-					wsum += weight
-					wa := scanLineF[i+3] * weight
-					r += scanLineF[i+0] * wa
-					g += scanLineF[i+1] * wa
-					b += scanLineF[i+2] * wa
-					a += wa
+				if newMax := x + radius + 1; newMax > max && newMax < src.w {
+					add(newMax, &r, &g, &b, &a)
+					wsum++
+					max = newMax
 				}
-				if a != 0 {
-					r /= a
-					g /= a
-					b /= a
+				if newMin := x - radius + 1; newMin > min {
+					i := min * 4
+					wa := float64(scanLine[i+3])
+					r -= float64(scanLine[i+0]) * wa
+					g -= float64(scanLine[i+1]) * wa
+					b -= float64(scanLine[i+2]) * wa
+					a -= wa
+					wsum--
+					min = newMin
 				}
-
-				scanLine[idx] = clamp(r)
-				scanLine[idx+1] = clamp(g)
-				scanLine[idx+2] = clamp(b)
-				scanLine[idx+3] = clamp(a / wsum)
 			}
-			copy(dst.Pix[y*dst.Stride:], scanLine)
+			copy(dst.Pix[y*dst.Stride:], out)
 		}
 	})
 
 	return dst
 }
 
-func blurVertical(img image.Image, kernel []float64) *image.NRGBA {
+// boxBlurVertical is the vertical counterpart of boxBlurHorizontal.
+func boxBlurVertical(img image.Image, w int) *image.NRGBA {
 	src := newScanner(img)
 	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
-	radius := len(kernel) - 1
+	if w <= 1 {
+		src.scan(0, 0, src.w, src.h, dst.Pix)
+		return dst
+	}
+	radius := w / 2
 
 	parallel(0, src.w, func(xs <-chan int) {
 		scanLine := make([]uint8, src.h*4)
-		scanLineF := make([]float64, len(scanLine))
 		for x := range xs {
 			src.scan(x, 0, x+1, src.h, scanLine)
-			for i, v := range scanLine {
-				scanLineF[i] = float64(v)
+
+			add := func(y int, r, g, b, a *float64) {
+				i := y * 4
+				wa := float64(scanLine[i+3])
+				*r += float64(scanLine[i+0]) * wa
+				*g += float64(scanLine[i+1]) * wa
+				*b += float64(scanLine[i+2]) * wa
+				*a += wa
+			}
+
+			var r, g, b, a, wsum float64
+			min, max := 0, -1
+			for iy := 0; iy <= radius && iy < src.h; iy++ {
+				add(iy, &r, &g, &b, &a)
+				wsum++
+				max = iy
 			}
-			for y, idx := 0, 0; y < src.h; y, idx = y+1, idx+4 {
-				min := y - radius
-				if min < 0 {
-					min = 0
+
+			for y := 0; y < src.h; y++ {
+				j := y*dst.Stride + x*4
+				if a != 0 {
+					dst.Pix[j+0] = clamp(r / a)
+					dst.Pix[j+1] = clamp(g / a)
+					dst.Pix[j+2] = clamp(b / a)
+				}
+				dst.Pix[j+3] = clamp(a / wsum)
+
+				if newMax := y + radius + 1; newMax > max && newMax < src.h {
+					add(newMax, &r, &g, &b, &a)
+					wsum++
+					max = newMax
 				}
-				max := y + radius
-				if max > src.h-1 {
-					max = src.h - 1
+				if newMin := y - radius + 1; newMin > min {
+					i := min * 4
+					wa := float64(scanLine[i+3])
+					r -= float64(scanLine[i+0]) * wa
+					g -= float64(scanLine[i+1]) * wa
+					b -= float64(scanLine[i+2]) * wa
+					a -= wa
+					wsum--
+					min = newMin
 				}
+			}
+		}
+	})
+
+	return dst
+}
+
+// UnsharpMask produces a sharpened version of the image using the unsharp
+// masking technique. It blurs the image with the given sigma and then, for
+// every channel whose difference from the blurred value exceeds threshold,
+// adds that difference back in scaled by amount; channels at or below
+// threshold are left untouched. This gives control over both the strength
+// of the sharpening and how much it is suppressed in smooth or noisy
+// regions, which a plain Sharpen cannot express. Alpha is copied from img
+// unchanged.
+//
+// Usage example:
+//
+//	dstImage := imaging.UnsharpMask(srcImage, 3.5, 1.5, 2)
+//
+func UnsharpMask(img image.Image, sigma, amount float64, threshold uint8) *image.NRGBA {
+	if sigma <= 0 {
+		return Clone(img)
+	}
+
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+	blurred := Blur(img, sigma)
+
+	parallel(0, src.h, func(ys <-chan int) {
+		scanLine := make([]uint8, src.w*4)
+		for y := range ys {
+			src.scan(0, y, src.w, y+1, scanLine)
+			j := y * dst.Stride
+			for x := 0; x < src.w; x++ {
+				for c := 0; c < 3; c++ {
+					i := j + x*4 + c
+					s := int(scanLine[x*4+c])
+					diff := s - int(blurred.Pix[i])
+					absDiff := diff
+					if absDiff < 0 {
+						absDiff = -absDiff
+					}
+					if absDiff <= int(threshold) {
+						dst.Pix[i] = uint8(s)
+						continue
+					}
+
+					val := int(float64(s) + amount*float64(diff))
+					if val < 0 {
+						val = 0
+					} else if val > 0xff {
+						val = 0xff
+					}
+					dst.Pix[i] = uint8(val)
+				}
+				dst.Pix[j+x*4+3] = scanLine[x*4+3]
+			}
+		}
+	})
+
+	return dst
+}
+
+// Sharpen produces a sharpened version of the image.
+// Sigma parameter must be positive and indicates how much the image will be sharpened.
+//
+// Usage example:
+//
+//	dstImage := imaging.Sharpen(srcImage, 3.5)
+//
+func Sharpen(img image.Image, sigma float64) *image.NRGBA {
+	return UnsharpMask(img, sigma, 1.0, 0)
+}
+
+// BlurBilateral produces an edge-preserving, denoised version of the image.
+// Each output pixel is a weighted average of its neighbours within radius
+// ceil(3*sigmaSpace), where the weight is the product of a spatial Gaussian
+// on pixel distance (sigmaSpace) and a range Gaussian on color distance
+// (sigmaRange). Because the range weight depends on the neighbour's color,
+// the filter does not blur across strong edges the way Blur does, which
+// makes it useful for denoising and skin smoothing. Since the range weight
+// breaks separability, the filter runs as a single 2D pass rather than the
+// two 1D passes Blur uses.
+//
+// Usage example:
+//
+//	dstImage := imaging.BlurBilateral(srcImage, 3.0, 30.0)
+//
+func BlurBilateral(img image.Image, sigmaSpace, sigmaRange float64) *image.NRGBA {
+	if sigmaSpace <= 0 || sigmaRange <= 0 {
+		return Clone(img)
+	}
+
+	src := newScanner(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
+
+	radius := int(math.Ceil(sigmaSpace * 3.0))
+	spaceKernel := make([][]float64, 2*radius+1)
+	for dy := -radius; dy <= radius; dy++ {
+		row := make([]float64, 2*radius+1)
+		for dx := -radius; dx <= radius; dx++ {
+			d2 := float64(dx*dx + dy*dy)
+			row[dx+radius] = math.Exp(-d2 / (2 * sigmaSpace * sigmaSpace))
+		}
+		spaceKernel[dy+radius] = row
+	}
+
+	// rangeKernel is indexed by the Euclidean color distance truncated to an
+	// integer, which for 8-bit RGB channels is at most floor(sqrt(3)*255) == 441,
+	// so 442 entries cover every index.
+	const rangeLUTSize = 442
+	rangeKernel := make([]float64, rangeLUTSize)
+	for i := range rangeKernel {
+		d := float64(i)
+		rangeKernel[i] = math.Exp(-(d * d) / (2 * sigmaRange * sigmaRange))
+	}
+
+	pix := make([]uint8, src.w*src.h*4)
+	src.scan(0, 0, src.w, src.h, pix)
+	stride := src.w * 4
+
+	at := func(x, y int) (float64, float64, float64, float64) {
+		if x < 0 {
+			x = 0
+		} else if x > src.w-1 {
+			x = src.w - 1
+		}
+		if y < 0 {
+			y = 0
+		} else if y > src.h-1 {
+			y = src.h - 1
+		}
+		i := y*stride + x*4
+		return float64(pix[i]), float64(pix[i+1]), float64(pix[i+2]), float64(pix[i+3])
+	}
+
+	parallel(0, src.h, func(ys <-chan int) {
+		for y := range ys {
+			j := y * dst.Stride
+			for x := 0; x < src.w; x++ {
+				cr, cg, cb, _ := at(x, y)
 
 				var r, g, b, a, wsum float64
-				for iy := min; iy <= max; iy++ {
-					i := iy * 4
-					weight := kernel[absint(y-iy)]
-					wsum += weight
-					v := scanLineF[i : i+4]
-					wa := v[3] * weight
-					r += v[0] * wa
-					g += v[1] * wa
-					b += v[2] * wa
-					a += wa
+				for dy := -radius; dy <= radius; dy++ {
+					row := spaceKernel[dy+radius]
+					for dx := -radius; dx <= radius; dx++ {
+						nr, ng, nb, na := at(x+dx, y+dy)
+						dr, dg, db := nr-cr, ng-cg, nb-cb
+						dist := math.Sqrt(dr*dr + dg*dg + db*db)
+						idx := int(dist)
+						if idx >= rangeLUTSize {
+							idx = rangeLUTSize - 1
+						}
+						weight := row[dx+radius] * rangeKernel[idx]
+
+						wa := na * weight
+						r += nr * wa
+						g += ng * wa
+						b += nb * wa
+						a += wa
+						wsum += weight
+					}
 				}
+
 				if a != 0 {
 					r /= a
 					g /= a
 					b /= a
 				}
 
-				j := y*dst.Stride + x*4
 				dst.Pix[j+0] = clamp(r)
 				dst.Pix[j+1] = clamp(g)
 				dst.Pix[j+2] = clamp(b)
 				dst.Pix[j+3] = clamp(a / wsum)
+				j += 4
 			}
 		}
 	})
@@ -136,36 +383,105 @@ func blurVertical(img image.Image, kernel []float64) *image.NRGBA {
 	return dst
 }
 
-// Sharpen produces a sharpened version of the image.
-// Sigma parameter must be positive and indicates how much the image will be sharpened.
+// BlurMotion produces a directional blur by sampling a 1-D Gaussian along a
+// straight line rather than blurring equally along both axes the way Blur
+// does. length is the total extent of the blur along the line in pixels (it
+// reaches length/2 pixels in each direction) and angleDeg (measured clockwise from the positive x axis) controls the
+// direction, producing camera-shake and speed-streak effects that an
+// axis-separable Gaussian cannot express.
 //
 // Usage example:
 //
-//	dstImage := imaging.Sharpen(srcImage, 3.5)
+//	dstImage := imaging.BlurMotion(srcImage, 20.0, 45.0)
 //
-func Sharpen(img image.Image, sigma float64) *image.NRGBA {
-	if sigma <= 0 {
+func BlurMotion(img image.Image, length float64, angleDeg float64) *image.NRGBA {
+	if length <= 0 {
 		return Clone(img)
 	}
 
 	src := newScanner(img)
 	dst := image.NewNRGBA(image.Rect(0, 0, src.w, src.h))
-	blurred := Blur(img, sigma)
+
+	taps := int(math.Ceil(length))
+	sigma := length / 6.0
+	if sigma <= 0 {
+		sigma = 1
+	}
+	theta := angleDeg * math.Pi / 180
+	dirX, dirY := math.Cos(theta), math.Sin(theta)
+
+	offsets := make([]float64, 2*taps+1)
+	weights := make([]float64, 2*taps+1)
+	var wsum float64
+	for i := -taps; i <= taps; i++ {
+		t := length * float64(i) / float64(taps) / 2
+		w := gaussianBlurKernel(t, sigma)
+		offsets[i+taps] = t
+		weights[i+taps] = w
+		wsum += w
+	}
+
+	sample := func(x, y float64) (float64, float64, float64, float64) {
+		x0 := int(math.Floor(x))
+		y0 := int(math.Floor(y))
+		fx := x - float64(x0)
+		fy := y - float64(y0)
+
+		var px [4]uint8
+		get := func(ix, iy int) (float64, float64, float64, float64) {
+			if ix < 0 {
+				ix = 0
+			} else if ix > src.w-1 {
+				ix = src.w - 1
+			}
+			if iy < 0 {
+				iy = 0
+			} else if iy > src.h-1 {
+				iy = src.h - 1
+			}
+			src.scan(ix, iy, ix+1, iy+1, px[:])
+			return float64(px[0]), float64(px[1]), float64(px[2]), float64(px[3])
+		}
+
+		r00, g00, b00, a00 := get(x0, y0)
+		r10, g10, b10, a10 := get(x0+1, y0)
+		r01, g01, b01, a01 := get(x0, y0+1)
+		r11, g11, b11, a11 := get(x0+1, y0+1)
+
+		lerp := func(v00, v10, v01, v11 float64) float64 {
+			top := v00 + (v10-v00)*fx
+			bottom := v01 + (v11-v01)*fx
+			return top + (bottom-top)*fy
+		}
+
+		return lerp(r00, r10, r01, r11), lerp(g00, g10, g01, g11), lerp(b00, b10, b01, b11), lerp(a00, a10, a01, a11)
+	}
 
 	parallel(0, src.h, func(ys <-chan int) {
-		scanLine := make([]uint8, src.w*4)
 		for y := range ys {
-			src.scan(0, y, src.w, y+1, scanLine)
 			j := y * dst.Stride
-			for i := 0; i < src.w*4; i++ {
-				val := int(scanLine[i])<<1 - int(blurred.Pix[j])
-				if val < 0 {
-					val = 0
-				} else if val > 0xff {
-					val = 0xff
+			for x := 0; x < src.w; x++ {
+				var r, g, b, a float64
+				for k, t := range offsets {
+					weight := weights[k]
+					sr, sg, sb, sa := sample(float64(x)+t*dirX, float64(y)+t*dirY)
+					wa := sa * weight
+					r += sr * wa
+					g += sg * wa
+					b += sb * wa
+					a += wa
+				}
+				if a != 0 {
+					r /= a
+					g /= a
+					b /= a
 				}
-				dst.Pix[j] = uint8(val)
-				j++
+
+				dst.Pix[j+0] = clamp(r)
+				dst.Pix[j+1] = clamp(g)
+				dst.Pix[j+2] = clamp(b)
+				dst.Pix[j+3] = clamp(a / wsum)
+				j += 4
 			}
 		}
 	})
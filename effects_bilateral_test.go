@@ -0,0 +1,62 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBlurBilateralNonPositiveParams(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	draw(src, color.NRGBA{10, 20, 30, 255})
+
+	for _, tc := range []struct{ sigmaSpace, sigmaRange float64 }{
+		{0, 10},
+		{10, 0},
+		{-1, 10},
+	} {
+		got := BlurBilateral(src, tc.sigmaSpace, tc.sigmaRange)
+		if !pixEqual(got, Clone(src)) {
+			t.Errorf("BlurBilateral(%v, %v) did not return a clone of the source", tc.sigmaSpace, tc.sigmaRange)
+		}
+	}
+}
+
+func TestBlurBilateralFlatColorIsUnchanged(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	want := color.NRGBA{90, 180, 40, 255}
+	draw(src, want)
+
+	dst := BlurBilateral(src, 3, 30)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		got := color.NRGBA{dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]}
+		if got != want {
+			t.Fatalf("BlurBilateral of a flat image changed pixel value: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBlurBilateralPreservesStrongEdge(t *testing.T) {
+	// A hard edge with a large color gap should be left mostly intact,
+	// since the range weight suppresses mixing across it.
+	src := image.NewNRGBA(image.Rect(0, 0, 20, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 20; x++ {
+			v := uint8(0)
+			if x >= 10 {
+				v = 255
+			}
+			src.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+
+	dst := BlurBilateral(src, 3, 10)
+	left := dst.NRGBAAt(2, 2).R
+	right := dst.NRGBAAt(17, 2).R
+	if left > 40 {
+		t.Errorf("left side of the edge drifted too far from black: got %d", left)
+	}
+	if right < 215 {
+		t.Errorf("right side of the edge drifted too far from white: got %d", right)
+	}
+}
@@ -0,0 +1,64 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBoxesForGauss(t *testing.T) {
+	boxes := boxesForGauss(10, 3)
+	if len(boxes) != 3 {
+		t.Fatalf("got %d boxes, want 3", len(boxes))
+	}
+	for _, w := range boxes {
+		if w%2 == 0 {
+			t.Errorf("box width %d is even, want odd", w)
+		}
+	}
+}
+
+func TestBlurFastNonPositiveSigma(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	draw(src, color.NRGBA{10, 20, 30, 255})
+
+	got := BlurFast(src, 0)
+	want := Clone(src)
+	if !pixEqual(got, want) {
+		t.Errorf("BlurFast with sigma<=0 did not return a clone of the source")
+	}
+}
+
+func TestBlurFastFlatColorIsUnchanged(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	want := color.NRGBA{200, 100, 50, 255}
+	draw(src, want)
+
+	dst := BlurFast(src, 5)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		got := color.NRGBA{dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]}
+		if got != want {
+			t.Fatalf("BlurFast of a flat image changed pixel value: got %v, want %v", got, want)
+		}
+	}
+}
+
+func draw(img *image.NRGBA, c color.NRGBA) {
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+}
+
+func pixEqual(a, b *image.NRGBA) bool {
+	if a.Rect != b.Rect {
+		return false
+	}
+	for i := range a.Pix {
+		if a.Pix[i] != b.Pix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBlurMotionNonPositiveLength(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	draw(src, color.NRGBA{1, 2, 3, 255})
+
+	got := BlurMotion(src, 0, 45)
+	if !pixEqual(got, Clone(src)) {
+		t.Errorf("BlurMotion with length<=0 did not return a clone of the source")
+	}
+}
+
+func TestBlurMotionFlatColorIsUnchanged(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	want := color.NRGBA{60, 120, 180, 255}
+	draw(src, want)
+
+	dst := BlurMotion(src, 10, 30)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		got := color.NRGBA{dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]}
+		if got != want {
+			t.Fatalf("BlurMotion of a flat image changed pixel value: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestBlurMotionPreservesDimensions(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 13, 9))
+	draw(src, color.NRGBA{5, 5, 5, 255})
+
+	dst := BlurMotion(src, 8, 0)
+	if dst.Rect.Dx() != 13 || dst.Rect.Dy() != 9 {
+		t.Errorf("BlurMotion changed image dimensions: got %v", dst.Rect)
+	}
+}
@@ -0,0 +1,42 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestUnsharpMaskBelowThresholdUnchanged(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	want := color.NRGBA{128, 64, 32, 255}
+	draw(src, want)
+
+	// A flat image has no difference from its blurred version, so every
+	// channel is at or below the threshold and should pass through unchanged.
+	dst := UnsharpMask(src, 2, 5, 0)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		got := color.NRGBA{dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3]}
+		if got != want {
+			t.Fatalf("UnsharpMask on a flat image changed pixel value: got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSharpenIsUnsharpMaskWrapper(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			v := uint8(0)
+			if x >= 4 {
+				v = 255
+			}
+			src.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+
+	got := Sharpen(src, 1.5)
+	want := UnsharpMask(src, 1.5, 1.0, 0)
+	if !pixEqual(got, want) {
+		t.Errorf("Sharpen(img, sigma) does not match UnsharpMask(img, sigma, 1.0, 0)")
+	}
+}